@@ -19,10 +19,13 @@ package main
 
 import (
 	"bytes"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io/fs"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"runtime"
 	"sort"
 	"strings"
@@ -30,6 +33,7 @@ import (
 
 	build "github.com/bazelbuild/buildifier/core"
 	"github.com/bazelbuild/buildifier/differ"
+	"github.com/bazelbuild/buildifier/warn"
 )
 
 var (
@@ -41,9 +45,20 @@ var (
 	mode  = flag.String("mode", "", "formatting mode: check, diff, or fix (default fix)")
 	path  = flag.String("path", "", "assume BUILD file has this path relative to the workspace directory")
 
+	format = flag.String("format", "text", "check mode output format: text or json")
+
+	recursive = flag.Bool("recursive", false, "walk the given directories recursively, formatting BUILD, BUILD.bazel, WORKSPACE, WORKSPACE.bazel and *.bzl files found along the way; named -recursive rather than -r, since -r already selects which rewrites to apply")
+	fileType  = flag.String("type", "default", "input file type: build, bzl, workspace, or default (inferred from the filename)")
+
 	// Debug flags passed through to rewrite.go
 	allowSort = stringList("allowsort", "additional sort contexts to treat as safe")
-	disable   = stringList("buildifier_disable", "list of buildifier rewrites to disable")
+
+	// Named-rewrite selection, modeled on go tool fix's -r and -force flags.
+	restrictRewrites = stringList("r", "comma-separated list of rewrites to apply; if set, only these run")
+	forceRewrites    = stringList("force", "comma-separated list of disabled-by-default rewrites to force on")
+
+	lint        = flag.String("lint", "off", "lint mode: off, warn, or fix")
+	lintWarning = flag.String("warnings", "", "comma-separated list of +warning/-warning overrides to the default lint warning set")
 )
 
 func stringList(name, help string) func() []string {
@@ -73,7 +88,23 @@ to the workspace directory. Normally buildifier deduces that path from the
 file names given, but the path can be given explicitly with the -path
 argument. This is especially useful when reformatting standard input,
 or in scripts that reformat a temporary copy of a file.
+
+With -recursive, the named arguments are directories to walk looking for
+BUILD, BUILD.bazel, WORKSPACE, WORKSPACE.bazel and *.bzl files, instead of
+files to format directly. The -type flag (build, bzl, workspace, or the
+default of inferring it from the filename) tells buildifier which set of
+language rules to apply, since some rewrites don't make sense for every
+file kind.
+
+Rewrites (select with -r, force disabled ones on with -force):
 `)
+	for _, rw := range build.Rewrites() {
+		mark := ""
+		if rw.Disabled {
+			mark = " (disabled by default)"
+		}
+		fmt.Fprintf(os.Stderr, "\t%s%s\n\t\t%s\n", rw.Name, mark, rw.Desc)
+	}
 	os.Exit(2)
 }
 
@@ -83,8 +114,9 @@ func main() {
 	args := flag.Args()
 
 	// Pass down debug flags into build package
-	build.DisableRewrites = disable()
 	build.AllowSort = allowSort()
+	build.RestrictRewrites = restrictRewrites()
+	build.ForceRewrites = forceRewrites()
 
 	if *dflag {
 		if *mode != "" {
@@ -107,6 +139,40 @@ func main() {
 		// ok
 	}
 
+	switch *fileType {
+	case "build", "bzl", "workspace", "default":
+		// ok
+	default:
+		fmt.Fprintf(os.Stderr, "buildifier: unrecognized type %s; valid types are build, bzl, workspace, default\n", *fileType)
+		os.Exit(2)
+	}
+
+	switch *format {
+	case "text", "json":
+		// ok
+	default:
+		fmt.Fprintf(os.Stderr, "buildifier: unrecognized format %s; valid formats are text, json\n", *format)
+		os.Exit(2)
+	}
+
+	switch *lint {
+	case "off", "warn", "fix":
+		// ok
+	default:
+		fmt.Fprintf(os.Stderr, "buildifier: unrecognized lint mode %s; valid modes are off, warn, fix\n", *lint)
+		os.Exit(2)
+	}
+	activeWarnings = warn.ParseSpec(*lintWarning)
+
+	if *recursive {
+		found, err := walkDirs(args)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "buildifier: %v\n", err)
+			os.Exit(3)
+		}
+		args = found
+	}
+
 	// If the path flag is set, must only be formatting a single file.
 	// It doesn't make sense for multiple files to have the same path.
 	if *path != "" && len(args) > 1 {
@@ -118,7 +184,7 @@ func main() {
 
 	// TODO(bazel-team): Handle "-" as stdin/stdout mode too.
 
-	if len(args) == 0 {
+	if !*recursive && len(args) == 0 {
 		// Read from stdin, write to stdout.
 		if *mode == "fix" {
 			*mode = "pipe"
@@ -130,13 +196,74 @@ func main() {
 
 	diff.Run()
 
-	for _, file := range toRemove {
-		os.Remove(file)
+	if *mode == "check" && *format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(jsonArray(diagnostics)); err != nil {
+			fmt.Fprintf(os.Stderr, "buildifier: %v\n", err)
+			exitCode = 3
+		}
 	}
 
 	os.Exit(exitCode)
 }
 
+// walkDirs recursively walks each of dirs, returning every BUILD, BUILD.bazel,
+// WORKSPACE, WORKSPACE.bazel and *.bzl file found along the way.
+func walkDirs(dirs []string) ([]string, error) {
+	var found []string
+	for _, dir := range dirs {
+		err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				return nil
+			}
+			if isBuildFilename(d.Name()) {
+				found = append(found, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return found, nil
+}
+
+// isBuildFilename reports whether name is a file buildifier knows how to
+// format when walking a directory tree with -recursive.
+func isBuildFilename(name string) bool {
+	switch name {
+	case "BUILD", "BUILD.bazel", "WORKSPACE", "WORKSPACE.bazel":
+		return true
+	}
+	return strings.HasSuffix(name, ".bzl")
+}
+
+// fileKind determines the build.FileType to use for filename, honoring an
+// explicit -type flag before falling back to inferring it from the name.
+func fileKind(filename string) build.FileType {
+	switch *fileType {
+	case "build":
+		return build.TypeBuild
+	case "bzl":
+		return build.TypeBzl
+	case "workspace":
+		return build.TypeWorkspace
+	}
+	base := filepath.Base(filename)
+	switch {
+	case base == "WORKSPACE" || base == "WORKSPACE.bazel":
+		return build.TypeWorkspace
+	case strings.HasSuffix(base, ".bzl"):
+		return build.TypeBzl
+	default:
+		return build.TypeBuild
+	}
+}
+
 func processFiles(files []string) {
 	// Start nworker workers reading stripes of the input
 	// argument list and sending the resulting data on
@@ -193,12 +320,51 @@ func processFiles(files []string) {
 // 3: unexpected runtime errors: file I/O problems or internal bugs
 var exitCode = 0
 
-// toRemove is a list of files to remove before exiting.
-var toRemove []string
-
 // diff is the differ to use when *mode == "diff".
 var diff *differ.Differ
 
+// activeWarnings is the set of lint warning names to check, computed once
+// in main from the -warnings flag.
+var activeWarnings map[string]bool
+
+// fileDiagnostic is the JSON-serializable record emitted for one file in
+// check mode when -format=json, for consumption by editors and CI systems
+// (GitHub Actions problem matchers, reviewdog, and similar).
+type fileDiagnostic struct {
+	Filename string   `json:"filename"`
+	Reformat bool     `json:"reformat"`
+	Rewrites []string `json:"rewrites,omitempty"`
+	Issues   []issue  `json:"issues,omitempty"`
+}
+
+// issue is a single lint finding located within a file, as reported by the
+// warn package when -lint is not off.
+type issue struct {
+	Name    string `json:"name"`
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+// diagnostics accumulates the per-file records for -format=json. It is
+// appended to from multiple processFile goroutines, guarded by diagMu.
+var (
+	diagMu      sync.Mutex
+	diagnostics []fileDiagnostic
+)
+
+// jsonArray returns diags, substituting an empty (non-nil) slice when it's
+// nil, so -format=json always encodes as a JSON array ("[]") and never as
+// "null" -- a nil slice is indistinguishable from "no files at all" to a
+// consumer, but the common "every file is clean" case still needs a valid
+// array to parse.
+func jsonArray(diags []fileDiagnostic) []fileDiagnostic {
+	if diags == nil {
+		return []fileDiagnostic{}
+	}
+	return diags
+}
+
 // processFile processes a single file containing data.
 // It has been read from filename and should be written back if fixing.
 func processFile(filename string) error {
@@ -227,56 +393,91 @@ func processFile(filename string) error {
 	}
 	beforeRewrite := build.Format(f)
 	var info build.RewriteInfo
-	build.Rewrite(f, &info)
+	build.Rewrite(f, fileKind(filename), &info)
+
+	var findings []*warn.Finding
+	if *lint != "off" {
+		for _, w := range warn.All() {
+			if !activeWarnings[w.Name] {
+				continue
+			}
+			fs := w.Check(f)
+			if len(fs) > 0 && *lint == "fix" && w.Fix != nil {
+				w.Fix(f)
+				fs = w.Check(f)
+			}
+			findings = append(findings, fs...)
+		}
+	}
 	ndata := build.Format(f)
 
+	if *lint != "off" && *format != "json" {
+		for _, finding := range findings {
+			fmt.Fprintf(os.Stderr, "%s:%d: [%s] %s\n", filename, finding.Line, finding.Name, finding.Message)
+		}
+	}
+
 	switch *mode {
 	case "check":
 		// check mode: print names of files that need formatting.
-		if !bytes.Equal(data, ndata) {
-			// Print:
-			//	name # list of what changed
-			reformat := ""
-			if !bytes.Equal(data, beforeRewrite) {
-				reformat = " reformat"
+		if !bytes.Equal(data, ndata) || len(findings) > 0 {
+			reformat := !bytes.Equal(data, beforeRewrite)
+
+			sort.Strings(info.Log)
+			var uniq []string
+			var last string
+			for _, s := range info.Log {
+				if s != last {
+					last = s
+					uniq = append(uniq, s)
+				}
 			}
-			var log string
-
-			if len(info.Log) > 0 && *showlog {
-				sort.Strings(info.Log)
-				var uniq []string
-				var last string
-				for _, s := range info.Log {
-					if s != last {
-						last = s
-						uniq = append(uniq, s)
-					}
+
+			if *format == "json" {
+				var issues []issue
+				for _, finding := range findings {
+					issues = append(issues, issue{
+						Name:    finding.Name,
+						Line:    finding.Line,
+						Column:  finding.Column,
+						Message: finding.Message,
+					})
+				}
+				diagMu.Lock()
+				diagnostics = append(diagnostics, fileDiagnostic{
+					Filename: filename,
+					Reformat: reformat,
+					Rewrites: uniq,
+					Issues:   issues,
+				})
+				diagMu.Unlock()
+			} else if !bytes.Equal(data, ndata) {
+				// Print:
+				//	name # list of what changed
+				reformatMark := ""
+				if reformat {
+					reformatMark = " reformat"
+				}
+				var log string
+				if len(uniq) > 0 && *showlog {
+					log = " " + strings.Join(uniq, " ")
 				}
-				log = " " + strings.Join(uniq, " ")
+				fmt.Printf("%s #%s %s%s\n", filename, reformatMark, &info, log)
 			}
-			fmt.Printf("%s #%s %s%s\n", filename, reformat, &info, log)
 		}
 		return nil
 
 	case "diff":
-		// diff mode: run diff on old and new.
+		// diff mode: queue a diff between old and new. The diffs are
+		// printed later by diff.Run, once every file has been processed.
 		if bytes.Equal(data, ndata) {
 			return nil
 		}
-		outfile, err := writeTemp(ndata)
-		if err != nil {
-			return err
-		}
-		infile := filename
-		if filename == "" {
-			// data was read from standard filename.
-			// Write it to a temporary file so diff can read it.
-			infile, err = writeTemp(data)
-			if err != nil {
-				return err
-			}
+		name := filename
+		if name == "stdin" {
+			name = "<stdin>"
 		}
-		diff.Show(infile, outfile)
+		diff.Show(name, data, ndata)
 
 	case "pipe":
 		// pipe mode - reading from stdin, writing to stdout.
@@ -303,19 +504,3 @@ func processFile(filename string) error {
 	}
 	return nil
 }
-
-// writeTemp writes data to a temporary file and returns the name of the file.
-func writeTemp(data []byte) (file string, err error) {
-	f, err := ioutil.TempFile("", "buildifier-tmp-")
-	if err != nil {
-		return "", fmt.Errorf("creating temporary file: %v", err)
-	}
-	name := f.Name()
-	toRemove = append(toRemove, name)
-	defer f.Close()
-	_, err = f.Write(data)
-	if err != nil {
-		return "", fmt.Errorf("writing temporary file: %v", err)
-	}
-	return name, nil
-}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestJSONArrayNeverEncodesAsNull(t *testing.T) {
+	b, err := json.Marshal(jsonArray(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(b); got != "[]" {
+		t.Fatalf("jsonArray(nil) encoded as %s, want []", got)
+	}
+}
+
+func TestJSONArrayPassesThroughNonNil(t *testing.T) {
+	diags := []fileDiagnostic{{Filename: "BUILD", Reformat: true}}
+	b, err := json.Marshal(jsonArray(diags))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `[{"filename":"BUILD","reformat":true}]`
+	if got := string(b); got != want {
+		t.Fatalf("jsonArray(diags) encoded as %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	build "github.com/bazelbuild/buildifier/core"
+)
+
+func TestIsBuildFilename(t *testing.T) {
+	cases := map[string]bool{
+		"BUILD":           true,
+		"BUILD.bazel":     true,
+		"WORKSPACE":       true,
+		"WORKSPACE.bazel": true,
+		"defs.bzl":        true,
+		"BUILD.bzl":       true,
+		"README.md":       false,
+		"BUILD.txt":       false,
+		"Makefile":        false,
+	}
+	for name, want := range cases {
+		if got := isBuildFilename(name); got != want {
+			t.Errorf("isBuildFilename(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestFileKindExplicitFlagOverridesInference(t *testing.T) {
+	old := *fileType
+	defer func() { *fileType = old }()
+
+	*fileType = "workspace"
+	if got := fileKind("pkg/BUILD"); got != build.TypeWorkspace {
+		t.Errorf("fileKind with -type=workspace = %v, want TypeWorkspace", got)
+	}
+
+	*fileType = "bzl"
+	if got := fileKind("WORKSPACE"); got != build.TypeBzl {
+		t.Errorf("fileKind with -type=bzl = %v, want TypeBzl", got)
+	}
+}
+
+func TestFileKindInfersFromName(t *testing.T) {
+	old := *fileType
+	*fileType = "default"
+	defer func() { *fileType = old }()
+
+	cases := map[string]build.FileType{
+		"pkg/BUILD":       build.TypeBuild,
+		"pkg/BUILD.bazel": build.TypeBuild,
+		"WORKSPACE":       build.TypeWorkspace,
+		"WORKSPACE.bazel": build.TypeWorkspace,
+		"rules/defs.bzl":  build.TypeBzl,
+		"stdin":           build.TypeBuild,
+	}
+	for name, want := range cases {
+		if got := fileKind(name); got != want {
+			t.Errorf("fileKind(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestWalkDirsFindsBuildFilesRecursively(t *testing.T) {
+	root := t.TempDir()
+	mustWrite := func(rel string) {
+		p := filepath.Join(root, rel)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(""), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mustWrite("BUILD")
+	mustWrite("WORKSPACE")
+	mustWrite("not_relevant.txt")
+	mustWrite("pkg/sub/BUILD.bazel")
+	mustWrite("pkg/sub/defs.bzl")
+
+	found, err := walkDirs([]string{root})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var gotRel []string
+	for _, f := range found {
+		rel, err := filepath.Rel(root, f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gotRel = append(gotRel, rel)
+	}
+	sort.Strings(gotRel)
+
+	want := []string{"BUILD", "WORKSPACE", filepath.Join("pkg", "sub", "BUILD.bazel"), filepath.Join("pkg", "sub", "defs.bzl")}
+	sort.Strings(want)
+
+	if len(gotRel) != len(want) {
+		t.Fatalf("walkDirs found %v, want %v", gotRel, want)
+	}
+	for i := range want {
+		if gotRel[i] != want[i] {
+			t.Fatalf("walkDirs found %v, want %v", gotRel, want)
+		}
+	}
+}
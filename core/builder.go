@@ -1,46 +1,86 @@
 package build
 
-import (
-	"bytes"
-	"html/template"
-	"log"
-)
-
-//NewBuildFile creates a BUILD
-func NewBuildFile() (*File, error) {
-	funcMap := template.FuncMap{}
+// NewFile creates a new, empty BUILD file at the given workspace-relative
+// path, ready to have loads and rules added to it with AddLoad and AddRule.
+// Unlike the old template-based generator this replaces, every node it
+// produces is a real AST node that round-trips through Format, so paths
+// and values containing quotes, "<" or "&" are never corrupted.
+func NewFile(path string) *File {
+	return &File{Path: path}
+}
 
-	const templateText = `
-{{.RuleName}}(
-    {{range $index, $element := .Attrs}}
-        "{{ $index }}" : [
-           {{ range $name := $element }}
-                "{{$name}}",
-           {{end}}
-        ],
-    {{end}}
-)
-`
-	tmpl, err := template.New("genrule").Funcs(funcMap).Parse(templateText)
-	if err != nil {
-		log.Fatalf("parsing: %s", err)
+// AddLoad appends a load("module", "sym1", "sym2", ...) statement to f.
+func (f *File) AddLoad(module string, symbols ...string) {
+	args := make([]Expr, 0, len(symbols)+1)
+	args = append(args, &StringExpr{Value: module})
+	for _, sym := range symbols {
+		args = append(args, &StringExpr{Value: sym})
 	}
+	f.Stmt = append(f.Stmt, &CallExpr{
+		X:    &Ident{Name: "load"},
+		List: args,
+	})
+}
+
+// RuleBuilder incrementally sets the attributes of a rule call (such as
+// go_library(name = "foo", srcs = [...])) that has already been appended
+// to a File by AddRule.
+type RuleBuilder struct {
+	call *CallExpr
+}
 
-	var b bytes.Buffer
-	type M map[string]interface{}
-	doc := M{
-		"RuleName": "go_library",
-		"Attrs": M{
-			"srcs": []string{"src.go"},
+// AddRule appends a new `kind(name = "name")` rule to f and returns a
+// RuleBuilder for setting its remaining attributes.
+func (f *File) AddRule(kind, name string) *RuleBuilder {
+	call := &CallExpr{
+		X: &Ident{Name: kind},
+		List: []Expr{
+			&BinaryExpr{
+				X:  &Ident{Name: "name"},
+				Op: "=",
+				Y:  &StringExpr{Value: name},
+			},
 		},
 	}
+	f.Stmt = append(f.Stmt, call)
+	return &RuleBuilder{call: call}
+}
 
-	err = tmpl.Execute(&b, doc)
-	if err != nil {
-		log.Fatalf("execution: %s", err)
+// attr returns the existing `name = ...` argument of the rule, or nil if
+// it hasn't been set yet.
+func (b *RuleBuilder) attr(name string) *BinaryExpr {
+	for _, arg := range b.call.List {
+		if bin, ok := arg.(*BinaryExpr); ok {
+			if id, ok := bin.X.(*Ident); ok && id.Name == name {
+				return bin
+			}
+		}
 	}
+	return nil
+}
 
-	log.Println(b.String())
+// SetAttr sets the named attribute to value, replacing any previous value.
+func (b *RuleBuilder) SetAttr(name string, value Expr) *RuleBuilder {
+	if bin := b.attr(name); bin != nil {
+		bin.Y = value
+		return b
+	}
+	b.call.List = append(b.call.List, &BinaryExpr{
+		X:  &Ident{Name: name},
+		Op: "=",
+		Y:  value,
+	})
+	return b
+}
 
-	return Parse("generated", b.Bytes())
+// AppendList appends items to the named list attribute, creating it as a
+// new list attribute if it doesn't already exist.
+func (b *RuleBuilder) AppendList(name string, items ...Expr) *RuleBuilder {
+	if bin := b.attr(name); bin != nil {
+		if list, ok := bin.Y.(*ListExpr); ok {
+			list.List = append(list.List, items...)
+			return b
+		}
+	}
+	return b.SetAttr(name, &ListExpr{List: items})
 }
@@ -3,14 +3,33 @@ package build
 import "testing"
 
 func TestCanCreateRules(t *testing.T) {
-	//attrs := map[string][]string{}
+	f := NewFile("BUILD")
+	f.AddLoad("@io_bazel_rules_go//go:def.bzl", "go_library")
+	f.AddRule("go_library", "go_default_library").
+		SetAttr("visibility", &ListExpr{List: []Expr{&StringExpr{Value: "//visibility:public"}}}).
+		AppendList("srcs", &StringExpr{Value: "src.go"})
 
-	//b.AddRule("go_library", attrs)
-	file, err := NewBuildFile()
-	if err != nil {
-		t.Fatal(err)
+	names := map[string]bool{}
+	for _, r := range f.Rules("") {
+		names[r.Name()] = true
 	}
-	for _, r := range file.Rules("") {
-		t.Log(r.Name())
+	if !names["go_default_library"] {
+		t.Fatalf("expected a rule named go_default_library, got %v", names)
+	}
+}
+
+func TestAppendListCreatesAttrIfMissing(t *testing.T) {
+	f := NewFile("BUILD")
+	b := f.AddRule("go_library", "go_default_library")
+	b.AppendList("srcs", &StringExpr{Value: "a.go"})
+	b.AppendList("srcs", &StringExpr{Value: "b.go"})
+
+	srcs := b.attr("srcs")
+	if srcs == nil {
+		t.Fatal("expected srcs attribute to be set")
+	}
+	list, ok := srcs.Y.(*ListExpr)
+	if !ok || len(list.List) != 2 {
+		t.Fatalf("expected srcs to be a 2-element list, got %#v", srcs.Y)
 	}
 }
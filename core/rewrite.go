@@ -0,0 +1,224 @@
+package build
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// RewriteInfo collects information about what Rewrite did, for use by
+// buildifier's check and diff modes.
+type RewriteInfo struct {
+	Log []string // names of the transformations that fired, one entry per edit
+}
+
+func (info *RewriteInfo) String() string {
+	counts := make(map[string]int)
+	for _, s := range info.Log {
+		counts[s]++
+	}
+	var names []string
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var parts []string
+	for _, name := range names {
+		if n := counts[name]; n > 1 {
+			parts = append(parts, fmt.Sprintf("%s:%d", name, n))
+		} else {
+			parts = append(parts, name)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// FileType identifies the kind of file being formatted, so that rewrites
+// can apply language-appropriate rules (for example, WORKSPACE files have
+// no place for a sorted load, since Bazel evaluates them top to bottom).
+type FileType int
+
+const (
+	TypeDefault FileType = iota // inferred from context; treat like TypeBuild
+	TypeBuild
+	TypeBzl
+	TypeWorkspace
+)
+
+// rewrite describes a single named, independently toggleable BUILD file
+// transformation, in the spirit of the fixes registered by go tool fix.
+type rewrite struct {
+	name     string
+	desc     string
+	disabled bool // true if this rewrite must be explicitly forced to run
+	fn       func(f *File, kind FileType, info *RewriteInfo)
+}
+
+// rewrites holds the registered rewrites in registration order.
+var rewrites []*rewrite
+
+// registerRewrite adds a named rewrite to the set Rewrite considers.
+// Rewrites are expected to register themselves from init functions.
+func registerRewrite(name, desc string, disabled bool, fn func(f *File, kind FileType, info *RewriteInfo)) {
+	rewrites = append(rewrites, &rewrite{name: name, desc: desc, disabled: disabled, fn: fn})
+}
+
+// RestrictRewrites, if non-empty, limits Rewrite to exactly the named
+// rewrites; ForceRewrites is not consulted in that case. It implements
+// buildifier's -r flag.
+var RestrictRewrites []string
+
+// ForceRewrites lists rewrites that should run even though they are
+// disabled by default. It implements buildifier's -force flag.
+var ForceRewrites []string
+
+// AllowSort lists additional sort contexts that should be treated as safe
+// to reorder, beyond the ones built into the sorting rewrites.
+var AllowSort []string
+
+// RewriteDescription describes one registered rewrite, for use by
+// buildifier -help.
+type RewriteDescription struct {
+	Name     string
+	Desc     string
+	Disabled bool
+}
+
+// Rewrites returns the registered rewrites, in registration order.
+func Rewrites() []RewriteDescription {
+	var out []RewriteDescription
+	for _, rw := range rewrites {
+		out = append(out, RewriteDescription{rw.name, rw.desc, rw.disabled})
+	}
+	return out
+}
+
+func stringSet(names []string) map[string]bool {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		if name != "" {
+			set[name] = true
+		}
+	}
+	return set
+}
+
+// Rewrite applies the active set of rewrites to f, in registration order,
+// recording what fired in info.
+//
+// By default every rewrite not marked disabled runs. If RestrictRewrites
+// is non-empty, only the named rewrites run (disabled ones included, same
+// as `go tool fix -r`). ForceRewrites additionally enables disabled
+// rewrites without having to name every other rewrite via RestrictRewrites.
+func Rewrite(f *File, kind FileType, info *RewriteInfo) {
+	restrict := stringSet(RestrictRewrites)
+	force := stringSet(ForceRewrites)
+	for _, rw := range rewrites {
+		switch {
+		case len(restrict) > 0:
+			if !restrict[rw.name] {
+				continue
+			}
+		case rw.disabled && !force[rw.name]:
+			continue
+		}
+		rw.fn(f, kind, info)
+	}
+}
+
+func init() {
+	registerRewrite("loadsort", "sort the symbols loaded by each load statement", false, sortLoadArgs)
+	registerRewrite("duplicateload", "merge multiple load statements from the same .bzl file", false, mergeDuplicateLoads)
+}
+
+// sortLoadArgs sorts the symbols named by each load() statement, rewriting
+// the statement's symbol arguments in place so the result round-trips
+// through Format. It is a no-op in WORKSPACE files, where repository rules
+// can shadow each other in load order and an automatic reorder could
+// silently change behavior.
+func sortLoadArgs(f *File, kind FileType, info *RewriteInfo) {
+	if kind == TypeWorkspace {
+		return
+	}
+	for _, s := range f.Stmt {
+		call, _, ok := asLoad(s)
+		if !ok {
+			continue
+		}
+		var symbols []string
+		for _, arg := range call.List[1:] {
+			if sym, ok := arg.(*StringExpr); ok {
+				symbols = append(symbols, sym.Value)
+			}
+		}
+		if len(symbols) != len(call.List)-1 || sort.StringsAreSorted(symbols) {
+			continue
+		}
+		sort.Strings(symbols)
+		for i, name := range symbols {
+			call.List[i+1] = &StringExpr{Value: name}
+		}
+		info.Log = append(info.Log, "loadsort")
+	}
+}
+
+// mergeDuplicateLoads merges multiple load() statements naming the same
+// .bzl file into one, unioning their symbols and dropping the now-redundant
+// statements from the file.
+func mergeDuplicateLoads(f *File, kind FileType, info *RewriteInfo) {
+	first := make(map[string]*CallExpr) // module -> first load() CallExpr seen
+	var changed bool
+	stmt := f.Stmt[:0:0]
+	for _, s := range f.Stmt {
+		call, module, ok := asLoad(s)
+		if !ok {
+			stmt = append(stmt, s)
+			continue
+		}
+		prev, dup := first[module]
+		if !dup {
+			first[module] = call
+			stmt = append(stmt, s)
+			continue
+		}
+
+		seen := make(map[string]bool)
+		for _, arg := range prev.List[1:] {
+			if sym, ok := arg.(*StringExpr); ok {
+				seen[sym.Value] = true
+			}
+		}
+		for _, arg := range call.List[1:] {
+			sym, ok := arg.(*StringExpr)
+			if !ok || seen[sym.Value] {
+				continue
+			}
+			prev.List = append(prev.List, arg)
+			seen[sym.Value] = true
+		}
+		info.Log = append(info.Log, "duplicateload")
+		changed = true
+		// Drop the duplicate statement by not appending it to stmt.
+	}
+	if changed {
+		f.Stmt = stmt
+	}
+}
+
+// asLoad reports whether s is a load("module", ...) statement, returning
+// its CallExpr and module string if so.
+func asLoad(s Expr) (*CallExpr, string, bool) {
+	call, ok := s.(*CallExpr)
+	if !ok {
+		return nil, "", false
+	}
+	ident, ok := call.X.(*Ident)
+	if !ok || ident.Name != "load" || len(call.List) == 0 {
+		return nil, "", false
+	}
+	module, ok := call.List[0].(*StringExpr)
+	if !ok {
+		return nil, "", false
+	}
+	return call, module.Value, true
+}
@@ -0,0 +1,57 @@
+package build
+
+import "testing"
+
+func TestRewriteRestrictAndForce(t *testing.T) {
+	var fired []string
+	registerRewrite("test-always", "test: always on", false, func(f *File, kind FileType, info *RewriteInfo) {
+		fired = append(fired, "test-always")
+	})
+	registerRewrite("test-disabled", "test: disabled by default", true, func(f *File, kind FileType, info *RewriteInfo) {
+		fired = append(fired, "test-disabled")
+	})
+	defer func() {
+		rewrites = rewrites[:len(rewrites)-2]
+	}()
+	defer func() {
+		RestrictRewrites = nil
+		ForceRewrites = nil
+	}()
+
+	f := &File{}
+
+	// Default: the always-on rewrite fires, the disabled one doesn't.
+	fired = nil
+	RestrictRewrites = nil
+	ForceRewrites = nil
+	Rewrite(f, TypeBuild, &RewriteInfo{})
+	if !hasName(fired, "test-always") || hasName(fired, "test-disabled") {
+		t.Fatalf("default run: got %v", fired)
+	}
+
+	// -force additionally enables the disabled rewrite.
+	fired = nil
+	ForceRewrites = []string{"test-disabled"}
+	Rewrite(f, TypeBuild, &RewriteInfo{})
+	if !hasName(fired, "test-always") || !hasName(fired, "test-disabled") {
+		t.Fatalf("force run: got %v", fired)
+	}
+	ForceRewrites = nil
+
+	// -r restricts the run to exactly the named rewrite, disabled or not.
+	fired = nil
+	RestrictRewrites = []string{"test-disabled"}
+	Rewrite(f, TypeBuild, &RewriteInfo{})
+	if hasName(fired, "test-always") || !hasName(fired, "test-disabled") {
+		t.Fatalf("restrict run: got %v", fired)
+	}
+}
+
+func hasName(list []string, name string) bool {
+	for _, s := range list {
+		if s == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,209 @@
+package differ
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// unifiedDiff writes a unified diff between before and after to w, with
+// name used as both the "---" and "+++" header.
+//
+// BUILD files are small, so this uses a straightforward LCS-based diff
+// (the same dynamic-programming construction the classic Myers algorithm
+// specializes for speed) rather than reaching for a streaming algorithm.
+func unifiedDiff(w io.Writer, name string, before, after []byte) {
+	a := splitLines(before)
+	b := splitLines(after)
+	ops := diffLines(a, b)
+
+	fmt.Fprintf(w, "--- %s\n+++ %s\n", name, name)
+	for _, h := range hunksOf(ops) {
+		writeHunk(w, h)
+	}
+}
+
+func splitLines(data []byte) []string {
+	if len(data) == 0 {
+		return nil
+	}
+	lines := strings.SplitAfter(string(data), "\n")
+	if last := lines[len(lines)-1]; last == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+type opKind int
+
+const (
+	opEqual opKind = iota
+	opDelete
+	opInsert
+)
+
+type op struct {
+	kind opKind
+	text string
+}
+
+// diffLines computes the edit script turning a into b via the longest
+// common subsequence.
+func diffLines(a, b []string) []op {
+	n, m := len(a), len(b)
+	// lcs[i][j] = length of the LCS of a[i:] and b[j:]
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var ops []op
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, op{kind: opEqual, text: a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, op{kind: opDelete, text: a[i]})
+			i++
+		default:
+			ops = append(ops, op{kind: opInsert, text: b[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		ops = append(ops, op{kind: opDelete, text: a[i]})
+	}
+	for ; j < m; j++ {
+		ops = append(ops, op{kind: opInsert, text: b[j]})
+	}
+	return ops
+}
+
+// hunk is a contiguous run of ops, padded with up to contextLines lines of
+// unchanged context on either side, in standard unified-diff style.
+type hunk struct {
+	aStart, bStart int // 0-based line numbers in a and b where the hunk begins
+	ops            []op
+}
+
+const contextLines = 3
+
+// hunksOf groups ops into hunks around each run of changes, merging two
+// runs whose surrounding context would otherwise overlap or touch.
+func hunksOf(ops []op) []hunk {
+	// aPos[k]/bPos[k] are how many lines of a/b respectively have been
+	// consumed by ops[:k], so they double as the 0-based line number that
+	// a hunk starting at ops[k] begins on.
+	aPos := make([]int, len(ops)+1)
+	bPos := make([]int, len(ops)+1)
+	for i, o := range ops {
+		aPos[i+1], bPos[i+1] = aPos[i], bPos[i]
+		switch o.kind {
+		case opEqual:
+			aPos[i+1]++
+			bPos[i+1]++
+		case opDelete:
+			aPos[i+1]++
+		case opInsert:
+			bPos[i+1]++
+		}
+	}
+
+	var changed []int
+	for i, o := range ops {
+		if o.kind != opEqual {
+			changed = append(changed, i)
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+
+	var hunks []hunk
+	i := 0
+	for i < len(changed) {
+		lo := changed[i] - contextLines
+		if lo < 0 {
+			lo = 0
+		}
+		hi := changed[i] + contextLines
+		j := i
+		for j+1 < len(changed) && changed[j+1]-changed[j] <= 2*contextLines+1 {
+			j++
+			hi = changed[j] + contextLines
+		}
+		if hi >= len(ops) {
+			hi = len(ops) - 1
+		}
+		hunks = append(hunks, hunk{
+			aStart: aPos[lo],
+			bStart: bPos[lo],
+			ops:    ops[lo : hi+1],
+		})
+		i = j + 1
+	}
+	return hunks
+}
+
+func writeHunk(w io.Writer, h hunk) {
+	var aCount, bCount int
+	for _, o := range h.ops {
+		switch o.kind {
+		case opEqual:
+			aCount++
+			bCount++
+		case opDelete:
+			aCount++
+		case opInsert:
+			bCount++
+		}
+	}
+	// A zero-length side reports the line before its (empty) range, which
+	// is 0 at the very start of the file, rather than the usual start+1 --
+	// matching the unified diff format and GNU diff's own output.
+	aLine := h.aStart + 1
+	if aCount == 0 {
+		aLine = h.aStart
+	}
+	bLine := h.bStart + 1
+	if bCount == 0 {
+		bLine = h.bStart
+	}
+	fmt.Fprintf(w, "@@ -%d,%d +%d,%d @@\n", aLine, aCount, bLine, bCount)
+
+	bw := bufio.NewWriter(w)
+	for _, o := range h.ops {
+		switch o.kind {
+		case opEqual:
+			bw.WriteString(" ")
+		case opDelete:
+			bw.WriteString("-")
+		case opInsert:
+			bw.WriteString("+")
+		}
+		line := o.text
+		if !strings.HasSuffix(line, "\n") {
+			bw.WriteString(line)
+			bw.WriteString("\n\\ No newline at end of file\n")
+			continue
+		}
+		bw.WriteString(line)
+	}
+	bw.Flush()
+}
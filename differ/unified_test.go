@@ -0,0 +1,91 @@
+package differ
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiffReplacedLine(t *testing.T) {
+	var buf bytes.Buffer
+	unifiedDiff(&buf, "BUILD", []byte("a\nb\nc\n"), []byte("a\nx\nc\n"))
+	out := buf.String()
+	if !strings.Contains(out, "-b\n") || !strings.Contains(out, "+x\n") {
+		t.Fatalf("expected b replaced by x, got:\n%s", out)
+	}
+}
+
+func TestUnifiedDiffNoChange(t *testing.T) {
+	var buf bytes.Buffer
+	unifiedDiff(&buf, "BUILD", []byte("a\n"), []byte("a\n"))
+	out := buf.String()
+	if strings.Contains(out, "@@") {
+		t.Fatalf("expected no hunks for identical input, got:\n%s", out)
+	}
+}
+
+// TestUnifiedDiffEmptySide covers the pure-insert-at-start-of-file case,
+// where the "before" side has zero lines: the hunk header must report a
+// start of 0, not 1, for the empty side (matching GNU diff).
+func TestUnifiedDiffEmptySide(t *testing.T) {
+	var buf bytes.Buffer
+	unifiedDiff(&buf, "BUILD", nil, []byte("a\nb\n"))
+	out := buf.String()
+	if !strings.Contains(out, "@@ -0,0 +1,2 @@") {
+		t.Fatalf("expected a zero-length before-side header, got:\n%s", out)
+	}
+}
+
+func TestUnifiedDiffEmptyAfter(t *testing.T) {
+	var buf bytes.Buffer
+	unifiedDiff(&buf, "BUILD", []byte("a\nb\n"), nil)
+	out := buf.String()
+	if !strings.Contains(out, "@@ -1,2 +0,0 @@") {
+		t.Fatalf("expected a zero-length after-side header, got:\n%s", out)
+	}
+}
+
+// buildDeletions returns a before/after pair where after is before with the
+// lines at indices del1 and del2 removed, leaving everything else as
+// unchanged context.
+func buildDeletions(total, del1, del2 int) (before, after []byte) {
+	var b, a bytes.Buffer
+	for i := 0; i < total; i++ {
+		line := fmt.Sprintf("line%d\n", i)
+		b.WriteString(line)
+		if i != del1 && i != del2 {
+			a.WriteString(line)
+		}
+	}
+	return b.Bytes(), a.Bytes()
+}
+
+// TestUnifiedDiffHunkMergeThreshold pins down the distance at which two
+// separate changes get folded into a single @@ hunk: GNU diff (and this
+// package) merge when the two changes are at most 2*contextLines unchanged
+// lines apart, and keep them in separate hunks otherwise.
+func TestUnifiedDiffHunkMergeThreshold(t *testing.T) {
+	cases := []struct {
+		gap       int // unchanged lines separating the two deletions
+		wantHunks int
+	}{
+		{gap: 2 * contextLines, wantHunks: 1},     // right at the merge boundary
+		{gap: 2*contextLines + 1, wantHunks: 2},   // one line too far apart to merge
+		{gap: 2*contextLines + 2, wantHunks: 2},   // previously merged by the buggy 3*contextLines threshold
+	}
+	for _, tc := range cases {
+		del1 := 5
+		del2 := del1 + tc.gap + 1
+		total := del2 + contextLines + 2
+		before, after := buildDeletions(total, del1, del2)
+
+		var buf bytes.Buffer
+		unifiedDiff(&buf, "BUILD", before, after)
+		out := buf.String()
+
+		if got := strings.Count(out, "@@ -"); got != tc.wantHunks {
+			t.Errorf("gap=%d: got %d hunks, want %d\n%s", tc.gap, got, tc.wantHunks, out)
+		}
+	}
+}
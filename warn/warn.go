@@ -0,0 +1,157 @@
+// Package warn implements buildifier's lint checks: a set of named,
+// independently toggleable warnings that can be reported (-lint=warn) or
+// automatically repaired (-lint=fix), modeled on how core.Rewrite gates
+// individual rewrites.
+package warn
+
+import (
+	"sort"
+	"strings"
+
+	build "github.com/bazelbuild/buildifier/core"
+)
+
+// Finding is a single lint issue located within a BUILD file.
+type Finding struct {
+	Name    string // name of the warning that produced this finding
+	Line    int
+	Column  int
+	Message string
+}
+
+// Warning describes one named lint check.
+type Warning struct {
+	Name     string
+	Desc     string
+	Disabled bool // true if this warning is off by default
+	Check    func(f *build.File) []*Finding
+	Fix      func(f *build.File) // nil if the warning has no automatic fix
+}
+
+var warnings []*Warning
+
+func register(w *Warning) {
+	warnings = append(warnings, w)
+}
+
+func init() {
+	register(&Warning{
+		Name:  "duplicate-name",
+		Desc:  "two rules in the same file share a name",
+		Check: checkDuplicateName,
+	})
+	register(&Warning{
+		Name:  "unsorted-srcs",
+		Desc:  "a rule's srcs attribute is not sorted",
+		Check: checkUnsortedSrcs,
+		Fix:   fixUnsortedSrcs,
+	})
+}
+
+// All returns the registered warnings, in registration order.
+func All() []*Warning {
+	return warnings
+}
+
+// DefaultSet returns the set of warning names active by default, i.e.
+// every registered warning that isn't Disabled.
+func DefaultSet() map[string]bool {
+	active := make(map[string]bool)
+	for _, w := range warnings {
+		if !w.Disabled {
+			active[w.Name] = true
+		}
+	}
+	return active
+}
+
+// ParseSpec applies a comma-separated list of +name/-name overrides (as
+// accepted by buildifier's -warnings flag) on top of DefaultSet, returning
+// the resulting active set. A bare name (no +/- prefix) is treated as +name.
+func ParseSpec(spec string) map[string]bool {
+	active := DefaultSet()
+	for _, tok := range strings.Split(spec, ",") {
+		tok = strings.TrimSpace(tok)
+		if tok == "" {
+			continue
+		}
+		switch tok[0] {
+		case '+':
+			active[tok[1:]] = true
+		case '-':
+			active[tok[1:]] = false
+		default:
+			active[tok] = true
+		}
+	}
+	return active
+}
+
+func checkDuplicateName(f *build.File) []*Finding {
+	var findings []*Finding
+	seen := make(map[string]bool)
+	for _, r := range f.Rules("") {
+		name := r.Name()
+		if name == "" {
+			continue
+		}
+		if seen[name] {
+			line, col := position(r)
+			findings = append(findings, &Finding{
+				Name:    "duplicate-name",
+				Line:    line,
+				Column:  col,
+				Message: "rule name \"" + name + "\" is used more than once in this file",
+			})
+			continue
+		}
+		seen[name] = true
+	}
+	return findings
+}
+
+func checkUnsortedSrcs(f *build.File) []*Finding {
+	var findings []*Finding
+	for _, r := range f.Rules("") {
+		srcs := r.AttrStrings("srcs")
+		if len(srcs) == 0 || sort.StringsAreSorted(srcs) {
+			continue
+		}
+		line, col := 0, 0
+		if attr := r.Attr("srcs"); attr != nil {
+			line, col = position(attr)
+		}
+		findings = append(findings, &Finding{
+			Name:    "unsorted-srcs",
+			Line:    line,
+			Column:  col,
+			Message: "srcs of \"" + r.Name() + "\" is not sorted",
+		})
+	}
+	return findings
+}
+
+// fixUnsortedSrcs sorts the srcs attribute of every rule that has one,
+// the same way core.sortLoadArgs sorts a load statement's symbols.
+func fixUnsortedSrcs(f *build.File) {
+	for _, r := range f.Rules("") {
+		srcs := r.AttrStrings("srcs")
+		if len(srcs) == 0 || sort.StringsAreSorted(srcs) {
+			continue
+		}
+		sorted := append([]string(nil), srcs...)
+		sort.Strings(sorted)
+		items := make([]build.Expr, len(sorted))
+		for i, s := range sorted {
+			items[i] = &build.StringExpr{Value: s}
+		}
+		r.SetAttr("srcs", &build.ListExpr{List: items})
+	}
+}
+
+// position reports the 1-based line and column an Expr (or Rule, which
+// embeds one) starts at, for locating lint findings in the original file.
+func position(n interface{ Span() (build.Position, build.Position) }) (line, col int) {
+	start, _ := n.Span()
+	return start.Line, start.LineRune
+}
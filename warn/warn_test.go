@@ -0,0 +1,100 @@
+package warn
+
+import (
+	"testing"
+
+	build "github.com/bazelbuild/buildifier/core"
+)
+
+func TestParseSpecDefaultsAreNonEmpty(t *testing.T) {
+	base := DefaultSet()
+	if len(base) == 0 {
+		t.Fatal("expected at least one warning enabled by default")
+	}
+	if !base["duplicate-name"] {
+		t.Fatal("expected duplicate-name to be enabled by default")
+	}
+}
+
+func TestParseSpecOverrides(t *testing.T) {
+	active := ParseSpec("-duplicate-name")
+	if active["duplicate-name"] {
+		t.Fatal("expected -duplicate-name to disable the warning")
+	}
+	if !active["unsorted-srcs"] {
+		t.Fatal("expected unsorted-srcs to remain enabled")
+	}
+
+	active = ParseSpec("-duplicate-name,+duplicate-name")
+	if !active["duplicate-name"] {
+		t.Fatal("expected a later +duplicate-name to re-enable it")
+	}
+
+	active = ParseSpec("unsorted-srcs")
+	if !active["unsorted-srcs"] {
+		t.Fatal("expected a bare name to behave like +name")
+	}
+}
+
+func TestCheckDuplicateName(t *testing.T) {
+	f := build.NewFile("pkg/BUILD")
+	f.AddRule("go_library", "foo")
+	f.AddRule("go_test", "foo")
+	f.AddRule("go_library", "bar")
+
+	findings := checkDuplicateName(f)
+	if len(findings) != 1 {
+		t.Fatalf("got %d findings, want 1: %v", len(findings), findings)
+	}
+	got := findings[0]
+	if got.Name != "duplicate-name" {
+		t.Errorf("Name = %q, want duplicate-name", got.Name)
+	}
+	if got.Message != `rule name "foo" is used more than once in this file` {
+		t.Errorf("unexpected message: %q", got.Message)
+	}
+}
+
+func TestCheckDuplicateNameNoDuplicates(t *testing.T) {
+	f := build.NewFile("pkg/BUILD")
+	f.AddRule("go_library", "foo")
+	f.AddRule("go_library", "bar")
+
+	if findings := checkDuplicateName(f); len(findings) != 0 {
+		t.Fatalf("got %d findings, want 0: %v", len(findings), findings)
+	}
+}
+
+func TestCheckUnsortedSrcs(t *testing.T) {
+	f := build.NewFile("pkg/BUILD")
+	f.AddRule("go_library", "foo").AppendList("srcs",
+		&build.StringExpr{Value: "b.go"},
+		&build.StringExpr{Value: "a.go"},
+	)
+
+	findings := checkUnsortedSrcs(f)
+	if len(findings) != 1 {
+		t.Fatalf("got %d findings, want 1: %v", len(findings), findings)
+	}
+	if got, want := findings[0].Message, `srcs of "foo" is not sorted`; got != want {
+		t.Errorf("Message = %q, want %q", got, want)
+	}
+}
+
+func TestFixUnsortedSrcs(t *testing.T) {
+	f := build.NewFile("pkg/BUILD")
+	f.AddRule("go_library", "foo").AppendList("srcs",
+		&build.StringExpr{Value: "b.go"},
+		&build.StringExpr{Value: "a.go"},
+	)
+
+	fixUnsortedSrcs(f)
+
+	if findings := checkUnsortedSrcs(f); len(findings) != 0 {
+		t.Fatalf("still unsorted after fixUnsortedSrcs: %v", findings)
+	}
+	srcs := f.Rules("")[0].AttrStrings("srcs")
+	if len(srcs) != 2 || srcs[0] != "a.go" || srcs[1] != "b.go" {
+		t.Fatalf("srcs = %v, want [a.go b.go]", srcs)
+	}
+}